@@ -0,0 +1,87 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package confgroup holds the typed config-group types discovery providers
+// emit and jobmgr consumes. A Group is always a full, self-consistent
+// snapshot of every backend a single source currently wants configured;
+// jobmgr diffs consecutive groups from the same source to find adds,
+// updates and removes.
+package confgroup
+
+import "reflect"
+
+// Config is one backend definition as discovered by a provider. Name+Source
+// together are the identity jobmgr tracks a running job under; the same
+// Name from two different Sources are independent jobs.
+type Config struct {
+	Source string
+	Name   string
+	Driver string
+	Values map[string]interface{}
+}
+
+// Key uniquely identifies a Config across all sources.
+func (c Config) Key() string {
+	return c.Source + ":" + c.Name
+}
+
+// Group is a full snapshot of the configs a single discovery source
+// currently wants running. An empty Configs slice means "this source wants
+// nothing running", not "no change" — providers must always emit their
+// complete current state.
+type Group struct {
+	Source  string
+	Configs []Config
+}
+
+// Diff compares Group (the new state) against prev (the last state seen
+// for the same source) and reports which configs were added, changed or
+// removed.
+func (g *Group) Diff(prev *Group) (added, updated, removed []Config) {
+	prevByKey := make(map[string]Config)
+	if prev != nil {
+		for _, c := range prev.Configs {
+			prevByKey[c.Key()] = c
+		}
+	}
+
+	seen := make(map[string]bool, len(g.Configs))
+	for _, c := range g.Configs {
+		seen[c.Key()] = true
+		old, ok := prevByKey[c.Key()]
+		switch {
+		case !ok:
+			added = append(added, c)
+		case !valuesEqual(old.Values, c.Values) || old.Driver != c.Driver:
+			updated = append(updated, c)
+		}
+	}
+	for key, c := range prevByKey {
+		if !seen[key] {
+			removed = append(removed, c)
+		}
+	}
+	return added, updated, removed
+}
+
+// valuesEqual compares two decoded config values for equality. Values come
+// from YAML/JSON-decoded backend definitions (see backends/discovery/file),
+// so they routinely hold []interface{} or map[string]interface{} beneath
+// the interface{} - dynamic types that panic on !=. reflect.DeepEqual
+// handles those the same way it's already used for comparisons elsewhere
+// in this series (e.g. nxlog.NxConfig.Equals).
+func valuesEqual(a, b map[string]interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
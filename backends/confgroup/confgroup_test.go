@@ -0,0 +1,85 @@
+package confgroup
+
+import "testing"
+
+func TestGroupDiffAddedUpdatedRemoved(t *testing.T) {
+	prev := &Group{
+		Source: "file",
+		Configs: []Config{
+			{Source: "file", Name: "a", Driver: "exec", Values: map[string]interface{}{"port": 514}},
+			{Source: "file", Name: "b", Driver: "exec", Values: map[string]interface{}{"port": 515}},
+		},
+	}
+	next := &Group{
+		Source: "file",
+		Configs: []Config{
+			{Source: "file", Name: "a", Driver: "exec", Values: map[string]interface{}{"port": 999}},
+			{Source: "file", Name: "c", Driver: "exec", Values: map[string]interface{}{"port": 516}},
+		},
+	}
+
+	added, updated, removed := next.Diff(prev)
+
+	if len(added) != 1 || added[0].Name != "c" {
+		t.Errorf("added = %+v, want just %q", added, "c")
+	}
+	if len(updated) != 1 || updated[0].Name != "a" {
+		t.Errorf("updated = %+v, want just %q", updated, "a")
+	}
+	if len(removed) != 1 || removed[0].Name != "b" {
+		t.Errorf("removed = %+v, want just %q", removed, "b")
+	}
+}
+
+func TestGroupDiffUnchangedConfigIsNotUpdated(t *testing.T) {
+	cfg := Config{Source: "file", Name: "a", Driver: "exec", Values: map[string]interface{}{
+		"tags": []interface{}{"one", "two"},
+		"nested": map[string]interface{}{
+			"enabled": true,
+		},
+	}}
+	prev := &Group{Source: "file", Configs: []Config{cfg}}
+	next := &Group{Source: "file", Configs: []Config{cfg}}
+
+	added, updated, removed := next.Diff(prev)
+	if len(added) != 0 || len(updated) != 0 || len(removed) != 0 {
+		t.Errorf("diffing an unchanged config with nested slice/map values got added=%+v updated=%+v removed=%+v, want no changes",
+			added, updated, removed)
+	}
+}
+
+func TestGroupDiffFirstSeenHasNoPrev(t *testing.T) {
+	next := &Group{Source: "file", Configs: []Config{
+		{Source: "file", Name: "a", Driver: "exec"},
+	}}
+
+	added, updated, removed := next.Diff(nil)
+	if len(added) != 1 || len(updated) != 0 || len(removed) != 0 {
+		t.Errorf("added=%+v updated=%+v removed=%+v, want exactly one added config", added, updated, removed)
+	}
+}
+
+func TestValuesEqualHandlesNestedSlicesAndMaps(t *testing.T) {
+	// This is the exact shape YAML/JSON decoding produces for a backend
+	// definition's values - a slice and a nested map under interface{}.
+	// Comparing these with != panics; valuesEqual must not.
+	a := map[string]interface{}{
+		"tags":   []interface{}{"one", "two"},
+		"module": map[string]interface{}{"enabled": true},
+	}
+	b := map[string]interface{}{
+		"tags":   []interface{}{"one", "two"},
+		"module": map[string]interface{}{"enabled": true},
+	}
+	if !valuesEqual(a, b) {
+		t.Error("valuesEqual(a, b) = false, want true for deeply equal nested values")
+	}
+
+	c := map[string]interface{}{
+		"tags":   []interface{}{"one", "three"},
+		"module": map[string]interface{}{"enabled": true},
+	}
+	if valuesEqual(a, c) {
+		t.Error("valuesEqual(a, c) = true, want false for differing nested slice contents")
+	}
+}
@@ -0,0 +1,73 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package discovery fans multiple backend-discovery providers (filesystem,
+// HTTP, static server assignment) into a single stream of confgroup.Group
+// events for daemon/jobmgr to consume.
+package discovery
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Graylog2/collector-sidecar/backends/confgroup"
+)
+
+// Provider watches one source of backend definitions (a config directory,
+// the Graylog server, ...) and emits a confgroup.Group on Run's out channel
+// every time its view of the world changes. Run blocks until ctx is
+// cancelled.
+type Provider interface {
+	// Source names this provider's Groups so jobmgr can tell them apart.
+	Source() string
+	Run(ctx context.Context, out chan<- *confgroup.Group)
+}
+
+// Manager runs a fixed set of providers concurrently and fans their Groups
+// into a single channel.
+type Manager struct {
+	providers []Provider
+	ch        chan *confgroup.Group
+}
+
+// NewManager builds a Manager over providers. Call Run to start them.
+func NewManager(providers ...Provider) *Manager {
+	return &Manager{
+		providers: providers,
+		ch:        make(chan *confgroup.Group),
+	}
+}
+
+// Events returns the channel every provider's Groups are fanned into.
+// jobmgr.Manager.Run reads from this channel.
+func (m *Manager) Events() <-chan *confgroup.Group {
+	return m.ch
+}
+
+// Run starts every provider in its own goroutine and blocks until ctx is
+// cancelled, at which point it waits for all providers to return before
+// closing Events().
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range m.providers {
+		wg.Add(1)
+		go func(p Provider) {
+			defer wg.Done()
+			p.Run(ctx, m.ch)
+		}(p)
+	}
+	wg.Wait()
+	close(m.ch)
+}
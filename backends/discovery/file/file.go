@@ -0,0 +1,112 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package file discovers backend definitions from YAML files under a
+// directory and re-emits them whenever the directory changes.
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v2"
+
+	"github.com/Graylog2/collector-sidecar/backends/confgroup"
+)
+
+// sourceName identifies Groups emitted by this provider.
+const sourceName = "file"
+
+// Provider watches Dir for *.yml/*.yaml files, each expected to contain a
+// single backend definition (top-level name, driver and values keys), and
+// re-emits the full set on every change. A file is not split into several
+// definitions; files that fail to decode or have no name are skipped.
+type Provider struct {
+	Dir string
+}
+
+// Source implements discovery.Provider.
+func (p *Provider) Source() string {
+	return sourceName
+}
+
+// Run implements discovery.Provider. It emits the initial state immediately
+// and again on every fsnotify event under Dir until ctx is cancelled.
+func (p *Provider) Run(ctx context.Context, out chan<- *confgroup.Group) {
+	p.emit(out)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	defer watcher.Close()
+	if err := watcher.Add(p.Dir); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			p.emit(out)
+		case <-watcher.Errors:
+			// best effort: keep watching after a watcher error
+		}
+	}
+}
+
+func (p *Provider) emit(out chan<- *confgroup.Group) {
+	matches, err := filepath.Glob(filepath.Join(p.Dir, "*.yml"))
+	if err != nil {
+		return
+	}
+	yamlMatches, err := filepath.Glob(filepath.Join(p.Dir, "*.yaml"))
+	if err == nil {
+		matches = append(matches, yamlMatches...)
+	}
+
+	var configs []confgroup.Config
+	for _, file := range matches {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var def struct {
+			Name   string                 `yaml:"name"`
+			Driver string                 `yaml:"driver"`
+			Values map[string]interface{} `yaml:"values"`
+		}
+		if err := yaml.Unmarshal(raw, &def); err != nil {
+			continue
+		}
+		if def.Name == "" {
+			continue
+		}
+		configs = append(configs, confgroup.Config{
+			Source: sourceName,
+			Name:   def.Name,
+			Driver: def.Driver,
+			Values: def.Values,
+		})
+	}
+
+	out <- &confgroup.Group{Source: sourceName, Configs: configs}
+}
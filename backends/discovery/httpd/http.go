@@ -0,0 +1,126 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package httpd discovers backend definitions by long-polling the Graylog
+// server's assignment endpoint. It is named httpd, not http, to avoid
+// shadowing net/http in importing files.
+package httpd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Graylog2/collector-sidecar/backends/confgroup"
+)
+
+// sourceName identifies Groups emitted by this provider.
+const sourceName = "server"
+
+// defaultPollTimeout bounds how long a single long-poll request is allowed
+// to hang waiting for the server to have something new to say.
+const defaultPollTimeout = 60 * time.Second
+
+// Provider long-polls URL for the sidecar's current backend assignment,
+// using If-None-Match/ETag so a 304 costs the server nothing when nothing
+// changed.
+type Provider struct {
+	URL    string
+	Client *http.Client
+
+	etag string
+}
+
+// Source implements discovery.Provider.
+func (p *Provider) Source() string {
+	return sourceName
+}
+
+// Run implements discovery.Provider. It polls URL in a loop, re-emitting
+// the assignment every time the server returns a fresh ETag, until ctx is
+// cancelled.
+func (p *Provider) Run(ctx context.Context, out chan<- *confgroup.Group) {
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultPollTimeout + 10*time.Second}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		group, err := p.poll(ctx, client)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		if group != nil {
+			out <- group
+		}
+	}
+}
+
+func (p *Provider) poll(ctx context.Context, client *http.Client) (*confgroup.Group, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+	req.Header.Set("X-Graylog-Poll-Timeout", defaultPollTimeout.String())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{resp.StatusCode}
+	}
+
+	var payload struct {
+		Configs []confgroup.Config `json:"configs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	p.etag = resp.Header.Get("ETag")
+
+	for i := range payload.Configs {
+		payload.Configs[i].Source = sourceName
+	}
+	return &confgroup.Group{Source: sourceName, Configs: payload.Configs}, nil
+}
+
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return http.StatusText(e.code)
+}
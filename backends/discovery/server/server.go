@@ -0,0 +1,55 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package server provides the statically-configured backend list from
+// UserConfig as a discovery source, letting a single sidecar host multiple
+// concurrent collectors (e.g. nxlog + filebeat + a custom binary), each
+// managed by jobmgr as an independent job, without requiring a file drop-in
+// or a reachable Graylog server.
+package server
+
+import (
+	"context"
+
+	"github.com/Graylog2/collector-sidecar/backends/confgroup"
+)
+
+// sourceName identifies Groups emitted by this provider.
+const sourceName = "static"
+
+// Provider emits a single, unchanging Group built from a statically known
+// list of backend definitions.
+type Provider struct {
+	Configs []confgroup.Config
+}
+
+// Source implements discovery.Provider.
+func (p *Provider) Source() string {
+	return sourceName
+}
+
+// Run implements discovery.Provider. The static list never changes after
+// sidecar startup, so Run emits exactly once and then blocks until ctx is
+// cancelled.
+func (p *Provider) Run(ctx context.Context, out chan<- *confgroup.Group) {
+	configs := make([]confgroup.Config, len(p.Configs))
+	for i, c := range p.Configs {
+		c.Source = sourceName
+		configs[i] = c
+	}
+	out <- &confgroup.Group{Source: sourceName, Configs: configs}
+
+	<-ctx.Done()
+}
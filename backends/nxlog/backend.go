@@ -0,0 +1,70 @@
+package nxlog
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/Graylog2/collector-sidecar/backends"
+)
+
+// Backend adapts NxConfig to backends.Backend so jobmgr can discover,
+// start and supervise nxlog the same way it does any other backend. It
+// embeds ConfigUpdater so an in-place config push (see ConfigUpdater.Update)
+// is picked up for free by jobmgr's Updater interface.
+type Backend struct {
+	ConfigUpdater
+	name     string
+	execPath string
+	execArgs []string
+}
+
+func (b *Backend) Name() string       { return b.name }
+func (b *Backend) ExecPath() string   { return b.execPath }
+func (b *Backend) ExecArgs() []string { return b.execArgs }
+
+// NewBackend builds a Backend from a discovered confgroup.Config's Values.
+// exec_path is required; everything else is the same class-keyed shape
+// Add/ConfigUpdater.Update already accept (e.g. values["input"] =
+// map[string]map[string]string{...}).
+func NewBackend(name string, values map[string]interface{}) (backends.Backend, error) {
+	execPath, _ := values["exec_path"].(string)
+	if execPath == "" {
+		return nil, fmt.Errorf("nxlog: config %q is missing required exec_path", name)
+	}
+	var execArgs []string
+	if raw, ok := values["exec_args"].([]interface{}); ok {
+		for _, a := range raw {
+			if s, ok := a.(string); ok {
+				execArgs = append(execArgs, s)
+			}
+		}
+	}
+
+	b := &Backend{
+		ConfigUpdater: ConfigUpdater{NxConfig: NewCollectorConfig(execPath)},
+		name:          name,
+		execPath:      execPath,
+		execArgs:      execArgs,
+	}
+
+	classValues := make(map[string]interface{}, len(values))
+	for class, v := range values {
+		if class == "exec_path" || class == "exec_args" {
+			continue
+		}
+		classValues[class] = v
+	}
+	if err := b.Update(classValues); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func init() {
+	if err := backends.Register("nxlog", NewBackend); err != nil {
+		hclog.Default().Error("failed to register nxlog backend", "error", err)
+		os.Exit(1)
+	}
+}
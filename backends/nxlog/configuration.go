@@ -1,9 +1,19 @@
 package nxlog
 
 import (
+	"fmt"
 	"reflect"
+
+	"github.com/hashicorp/go-hclog"
 )
 
+// ConfigPusher delivers a config delta to the running collector process
+// without restarting it, over whatever channel the runner supervising that
+// process owns (e.g. daemon.ExecRunner's loopback gRPC control channel).
+type ConfigPusher interface {
+	PushConfig(values map[string]interface{}) error
+}
+
 type NxConfig struct {
 	CollectorPath string
 	Definitions   []nxdefinition
@@ -14,6 +24,20 @@ type NxConfig struct {
 	Routes        []nxroute
 	Matches       []nxmatch
 	Snippets      []nxsnippet
+
+	// Logger carries backend=nxlog plus whatever runner/pid fields the
+	// caller has already attached; it defaults to a no-op logger so
+	// NxConfig remains usable without one, e.g. in tests.
+	Logger hclog.Logger
+
+	// Pusher, if set, is used by Update to propagate the merged inputs,
+	// outputs and routes (each keyed by name) to the already-running
+	// collector over the wire instead of relying on the caller to rewrite
+	// the on-disk config and restart the process. Definitions, paths,
+	// extensions, matches and snippets are not pushed.
+	// It is nil until whatever constructs the running job wires it up
+	// (e.g. to the owning daemon.ExecRunner), so it defaults to a no-op.
+	Pusher ConfigPusher
 }
 
 type nxdefinition struct {
@@ -59,6 +83,7 @@ type nxsnippet struct {
 func NewCollectorConfig(collectorPath string) *NxConfig {
 	nxc := &NxConfig{
 		CollectorPath: collectorPath,
+		Logger:        hclog.NewNullLogger(),
 		Definitions:   []nxdefinition{{name: "ROOT", value: collectorPath}},
 		Paths: []nxpath{{name: "Moduledir", path: "%ROOT%\\modules"},
 			{name: "CacheDir", path: "%ROOT%\\data"},
@@ -93,21 +118,85 @@ func (nxc *NxConfig) Add(class string, name string, value interface{}) {
 	}
 }
 func (nxc *NxConfig) Update(a *NxConfig) {
+	nxc.Logger.Debug("updating configuration",
+		"inputs", len(a.Inputs), "outputs", len(a.Outputs), "routes", len(a.Routes))
+
 	nxc.CollectorPath = a.CollectorPath
-	nxc.Definitions   = a.Definitions
-	nxc.Paths         = a.Paths
-	nxc.Extensions    = a.Extensions
-	nxc.Inputs        = a.Inputs
-	nxc.Outputs       = a.Outputs
-	nxc.Routes        = a.Routes
-	nxc.Matches       = a.Matches
-	nxc.Snippets      = a.Snippets
+	nxc.Definitions = a.Definitions
+	nxc.Paths = a.Paths
+	nxc.Extensions = a.Extensions
+	nxc.Inputs = a.Inputs
+	nxc.Outputs = a.Outputs
+	nxc.Routes = a.Routes
+	nxc.Matches = a.Matches
+	nxc.Snippets = a.Snippets
+
+	if nxc.Pusher == nil {
+		return
+	}
+	// The nx* entry types have only unexported fields, which encoding/json
+	// would drop, so they are pushed as name -> properties maps.
+	inputs := make(map[string]map[string]string, len(nxc.Inputs))
+	for _, i := range nxc.Inputs {
+		inputs[i.name] = i.properties
+	}
+	outputs := make(map[string]map[string]string, len(nxc.Outputs))
+	for _, o := range nxc.Outputs {
+		outputs[o.name] = o.properties
+	}
+	routes := make(map[string]map[string]string, len(nxc.Routes))
+	for _, r := range nxc.Routes {
+		routes[r.name] = r.properties
+	}
+	if err := nxc.Pusher.PushConfig(map[string]interface{}{
+		"inputs":  inputs,
+		"outputs": outputs,
+		"routes":  routes,
+	}); err != nil {
+		nxc.Logger.Warn("failed to push updated configuration to the running collector", "error", err)
+	}
 }
 
 func (nxc *NxConfig) Equals(a *NxConfig) bool {
-	return reflect.DeepEqual(nxc, a)
+	// Logger and Pusher are infrastructure, not configuration; comparing
+	// them would make two otherwise-identical configs built with
+	// different logger/pusher instances look unequal.
+	left, right := *nxc, *a
+	left.Logger, right.Logger = nil, nil
+	left.Pusher, right.Pusher = nil, nil
+	return reflect.DeepEqual(left, right)
 }
 
 func (nxc *NxConfig) GetCollectorPath() string {
 	return nxc.CollectorPath
-}
\ No newline at end of file
+}
+
+// ConfigUpdater adapts NxConfig to daemon/jobmgr.Updater
+// (Update(map[string]interface{}) error). It can't be a method on
+// NxConfig itself since that name is already taken by the
+// Update(*NxConfig) merge entrypoint above; Backend (see backend.go)
+// embeds ConfigUpdater to get jobmgr's in-place config updates for free.
+//
+// values uses the same class-keyed shape Add already accepts, e.g.
+// values["input"] = map[string]map[string]string{"eventlog": {...}},
+// since that's the shape discovery providers decode collector configs
+// into.
+type ConfigUpdater struct {
+	*NxConfig
+}
+
+func (u *ConfigUpdater) Update(values map[string]interface{}) error {
+	for class, raw := range values {
+		entries, ok := raw.(map[string]map[string]string)
+		if !ok {
+			return fmt.Errorf("nxlog: %s value has unexpected type %T", class, raw)
+		}
+		for name, properties := range entries {
+			u.Add(class, name, properties)
+		}
+	}
+	if u.Pusher == nil {
+		return nil
+	}
+	return u.Pusher.PushConfig(values)
+}
@@ -0,0 +1,57 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Factory builds a Backend of the given name from its raw, decoded config
+// values. Concrete backend packages register themselves by driver from
+// their own init(), the same way daemon.RegisterBackendRunner lets a
+// daemon.Runner implementation register itself by driver.
+type Factory func(name string, values map[string]interface{}) (Backend, error)
+
+var (
+	factoriesMu sync.Mutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes driver constructible via NewFromConfig. It returns an
+// error if driver is already registered.
+func Register(driver string, factory Factory) error {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, exists := factories[driver]; exists {
+		return fmt.Errorf("backend driver %q is already registered", driver)
+	}
+	factories[driver] = factory
+	return nil
+}
+
+// NewFromConfig builds the Backend for driver, as discovered by
+// backends/discovery and reconciled by daemon/jobmgr. It returns an error
+// if no backend package has registered driver yet.
+func NewFromConfig(driver, name string, values map[string]interface{}) (Backend, error) {
+	factoriesMu.Lock()
+	factory, ok := factories[driver]
+	factoriesMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no backend registered for driver %q", driver)
+	}
+	return factory(name, values)
+}
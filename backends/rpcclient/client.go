@@ -0,0 +1,128 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package rpcclient is the Go client library collector wrappers embed to
+// talk to the sidecar's loopback control channel. Collectors that don't
+// speak the protocol natively (nxlog, filebeat) can instead be fronted by a
+// thin shim process that links this package and forwards config/status on
+// their behalf.
+package rpcclient
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/Graylog2/collector-sidecar/backends/rpcclient/proto"
+)
+
+// Env vars the supervisor injects into every ExecRunner child before it is
+// started.
+const (
+	EnvControlAddr  = "GRAYLOG_CONTROL_ADDR"
+	EnvControlToken = "GRAYLOG_CONTROL_TOKEN"
+)
+
+// Client is a connected session to the supervisor's control channel.
+type Client struct {
+	conn    *grpc.ClientConn
+	stream  pb.Control_SessionClient
+	Configs <-chan *pb.ConfigUpdate
+}
+
+// Dial connects to the control endpoint and token found in the process
+// environment (as injected by daemon.ExecRunner) and sends the initial
+// CheckIn. It does not wait for the supervisor's CheckInAck; a rejected
+// check-in shows up as the stream, and with it Configs, closing.
+// backendName identifies the caller in logs on the supervisor side.
+func Dial(backendName string) (*Client, error) {
+	addr := os.Getenv(EnvControlAddr)
+	token := os.Getenv(EnvControlToken)
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := pb.NewControlClient(conn).Session(context.Background())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := stream.Send(&pb.ClientMessage{Payload: &pb.ClientMessage_CheckIn{
+		CheckIn: &pb.CheckIn{
+			Token:       token,
+			BackendName: backendName,
+			Pid:         int32(os.Getpid()),
+		},
+	}}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	configs := make(chan *pb.ConfigUpdate)
+	c := &Client{conn: conn, stream: stream, Configs: configs}
+	go c.recvLoop(configs)
+
+	return c, nil
+}
+
+// recvLoop forwards ConfigUpdate messages from the supervisor onto Configs
+// until the stream closes.
+func (c *Client) recvLoop(configs chan<- *pb.ConfigUpdate) {
+	defer close(configs)
+	for {
+		msg, err := c.stream.Recv()
+		if err != nil {
+			return
+		}
+		if update := msg.GetConfigUpdate(); update != nil {
+			configs <- update
+		}
+	}
+}
+
+// Heartbeat sends a single liveness ping. Callers should call this on a
+// fixed interval shorter than the deadline the supervisor acked at CheckIn.
+func (c *Client) Heartbeat() error {
+	return c.stream.Send(&pb.ClientMessage{Payload: &pb.ClientMessage_Heartbeat{
+		Heartbeat: &pb.Heartbeat{UnixTimeMs: time.Now().UnixNano() / int64(time.Millisecond)},
+	}})
+}
+
+// SendStatus reports a structured status transition, replacing the old
+// "exits immediately" heuristic with an explicit signal from the collector.
+func (c *Client) SendStatus(status, message string) error {
+	return c.stream.Send(&pb.ClientMessage{Payload: &pb.ClientMessage_Status{
+		Status: &pb.StatusTransition{Status: status, Message: message},
+	}})
+}
+
+// SendEvent streams a single collected log/metric event back for
+// forwarding.
+func (c *Client) SendEvent(source string, payload []byte) error {
+	return c.stream.Send(&pb.ClientMessage{Payload: &pb.ClientMessage_Event{
+		Event: &pb.Event{Source: source, Payload: payload},
+	}})
+}
+
+// Close shuts down the stream and the underlying connection.
+func (c *Client) Close() error {
+	c.stream.CloseSend()
+	return c.conn.Close()
+}
@@ -0,0 +1,91 @@
+package proto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestCheckInRoundTrip guards against control.pb.go regressing into a
+// struct with no working (un)marshal path: proto.Marshal on a type with
+// neither correct protobuf struct tags nor a Marshal() method silently
+// encodes to zero bytes, which makes every check-in token compare equal
+// to "" - see control_server.go's Session handshake.
+func TestCheckInRoundTrip(t *testing.T) {
+	want := &CheckIn{Token: "abc123", BackendName: "nxlog", Pid: 42}
+	b, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if len(b) == 0 {
+		t.Fatal("Marshal() produced zero bytes for a populated CheckIn")
+	}
+
+	got := &CheckIn{}
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Token != want.Token || got.BackendName != want.BackendName || got.Pid != want.Pid {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckInAckRoundTrip(t *testing.T) {
+	want := &CheckInAck{Accepted: true, HeartbeatDeadlineMs: 30000}
+	b, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := &CheckInAck{}
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Accepted != want.Accepted || got.HeartbeatDeadlineMs != want.HeartbeatDeadlineMs {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestClientMessageOneofRoundTrip(t *testing.T) {
+	want := &ClientMessage{Payload: &ClientMessage_Event{
+		Event: &Event{Source: "nxlog", Payload: []byte{1, 2, 3}},
+	}}
+	b, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := &ClientMessage{}
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	ev := got.GetEvent()
+	if ev == nil || ev.Source != "nxlog" || !bytes.Equal(ev.Payload, []byte{1, 2, 3}) {
+		t.Errorf("round trip payload = %+v, want Event{Source: nxlog, Payload: [1 2 3]}", got.GetPayload())
+	}
+}
+
+func TestServerMessageConfigUpdateRoundTrip(t *testing.T) {
+	want := &ServerMessage{Payload: &ServerMessage_ConfigUpdate{
+		ConfigUpdate: &ConfigUpdate{Format: "json", Payload: []byte(`{"a":1}`)},
+	}}
+	b, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := &ServerMessage{}
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	cu := got.GetConfigUpdate()
+	if cu == nil || cu.Format != "json" || string(cu.Payload) != `{"a":1}` {
+		t.Errorf("round trip payload = %+v, want ConfigUpdate{Format: json, Payload: {\"a\":1}}", got.GetPayload())
+	}
+}
+
+func TestClientMessageNilPayloadMarshalsToZeroBytes(t *testing.T) {
+	b, err := (&ClientMessage{}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if len(b) != 0 {
+		t.Errorf("Marshal() of an unset oneof = %v, want zero bytes", b)
+	}
+}
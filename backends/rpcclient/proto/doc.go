@@ -0,0 +1,5 @@
+// Package proto holds the generated gRPC stubs for the supervisor <-> collector
+// control channel defined in control.proto.
+//
+//go:generate protoc --go_out=plugins=grpc:. control.proto
+package proto
@@ -0,0 +1,190 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+package daemon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+
+	pb "github.com/Graylog2/collector-sidecar/backends/rpcclient/proto"
+)
+
+// defaultHeartbeatDeadline is handed to the collector at CheckIn time; a
+// collector that stays silent for longer than this is considered
+// unresponsive, and ExecRunner kills its process so the restart policy
+// decides whether it is started again.
+const defaultHeartbeatDeadline = 30 * time.Second
+
+// controlServer is the loopback gRPC server ExecRunner starts before
+// spawning its collector process. One instance backs exactly one process
+// generation; a new token and listener are issued on every (re)start.
+type controlServer struct {
+	name   string
+	token  string
+	logger hclog.Logger
+
+	srv      *grpc.Server
+	listener net.Listener
+
+	mu            sync.Mutex
+	lastHeartbeat time.Time
+	checkedInOnce sync.Once
+	checkedIn     chan struct{}
+	configUpdates chan *pb.ConfigUpdate
+}
+
+func newControlServer(name string, logger hclog.Logger) (*controlServer, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	return &controlServer{
+		name:          name,
+		token:         token,
+		logger:        logger,
+		checkedIn:     make(chan struct{}),
+		configUpdates: make(chan *pb.ConfigUpdate, 1),
+	}, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Start binds a loopback listener and begins serving. It returns the
+// address to inject into the collector's environment.
+func (c *controlServer) Start() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	c.listener = l
+	c.srv = grpc.NewServer()
+	pb.RegisterControlServer(c.srv, c)
+	go c.srv.Serve(l)
+	return l.Addr().String(), nil
+}
+
+// Stop tears down the gRPC server and its listener.
+func (c *controlServer) Stop() {
+	if c.srv != nil {
+		c.srv.Stop()
+	}
+}
+
+// WaitForCheckIn blocks until the collector completes its CheckIn handshake
+// or timeout elapses.
+func (c *controlServer) WaitForCheckIn(timeout time.Duration) error {
+	select {
+	case <-c.checkedIn:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("[%s] collector did not check in over the control channel within %s", c.name, timeout)
+	}
+}
+
+// PushConfig queues a config delta to be streamed to the collector without
+// restarting the process. It is dropped if a previous update is still
+// pending delivery.
+func (c *controlServer) PushConfig(update *pb.ConfigUpdate) {
+	select {
+	case c.configUpdates <- update:
+	default:
+	}
+}
+
+// MissedHeartbeat reports whether the collector has gone silent longer than
+// defaultHeartbeatDeadline, which ExecRunner treats as a liveness failure.
+func (c *controlServer) MissedHeartbeat() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastHeartbeat.IsZero() {
+		return false
+	}
+	return time.Since(c.lastHeartbeat) > defaultHeartbeatDeadline
+}
+
+// Session implements pb.ControlServer. Exactly one collector is expected to
+// dial in per process generation.
+func (c *controlServer) Session(stream pb.Control_SessionServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	checkIn := msg.GetCheckIn()
+	if checkIn == nil || checkIn.Token != c.token {
+		return fmt.Errorf("[%s] control channel: missing or invalid check-in token", c.name)
+	}
+
+	if err := stream.Send(&pb.ServerMessage{Payload: &pb.ServerMessage_CheckInAck{
+		CheckInAck: &pb.CheckInAck{
+			Accepted:            true,
+			HeartbeatDeadlineMs: int64(defaultHeartbeatDeadline / time.Millisecond),
+		},
+	}}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.lastHeartbeat = time.Now()
+	c.mu.Unlock()
+	c.checkedInOnce.Do(func() { close(c.checkedIn) })
+
+	errc := make(chan error, 1)
+	go c.recvLoop(stream, errc)
+
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case update := <-c.configUpdates:
+			if err := stream.Send(&pb.ServerMessage{Payload: &pb.ServerMessage_ConfigUpdate{ConfigUpdate: update}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *controlServer) recvLoop(stream pb.Control_SessionServer, errc chan<- error) {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			errc <- err
+			return
+		}
+		switch payload := msg.Payload.(type) {
+		case *pb.ClientMessage_Heartbeat:
+			c.mu.Lock()
+			c.lastHeartbeat = time.Now()
+			c.mu.Unlock()
+		case *pb.ClientMessage_Status:
+			c.logger.Info("collector status", "status", payload.Status.Status, "message", payload.Status.Message)
+		case *pb.ClientMessage_Event:
+			c.logger.Debug("collector event", "source", payload.Event.Source, "bytes", len(payload.Event.Payload))
+		}
+	}
+}
@@ -16,6 +16,8 @@
 package daemon
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -23,11 +25,16 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/kardianos/service"
 
 	"github.com/Graylog2/collector-sidecar/backends"
+	"github.com/Graylog2/collector-sidecar/backends/rpcclient"
+	pb "github.com/Graylog2/collector-sidecar/backends/rpcclient/proto"
 	"github.com/Graylog2/collector-sidecar/common"
 	"github.com/Graylog2/collector-sidecar/context"
+	"github.com/Graylog2/collector-sidecar/daemon/filelock"
+	"github.com/Graylog2/collector-sidecar/daemon/filestatus"
 )
 
 type ExecRunner struct {
@@ -36,20 +43,50 @@ type ExecRunner struct {
 	args           []string
 	stderr, stdout string
 	isRunning      bool
-	restartCount   int
+	stopRequested  bool
+	attempt        int
 	startTime      time.Time
+	startFailed    error
+	policyConfig   RestartPolicyConfig
+	policy         RestartPolicy
+	control        *controlServer
+	sampler        *statsSampler
+	lock           *filelock.Lock
 	cmd            *exec.Cmd
 	service        service.Service
 	wg             sync.WaitGroup
+	logger         hclog.Logger
+}
+
+// statsPublisher is implemented by backend definitions that want resource
+// usage samples pushed to the Graylog server as they're collected, e.g.
+// through the server's existing API client. No backend in this tree
+// implements it yet, so today sampling is local-only: LatestStats/
+// StatsReporter still expose it to whatever does.
+type statsPublisher interface {
+	PublishStats(usage *BackendResourceUsage) error
+}
+
+// controlCheckInTimeout bounds how long Start waits for a freshly spawned
+// collector to dial back over the control channel before giving up on it
+// for this attempt.
+const controlCheckInTimeout = 10 * time.Second
+
+// restartPolicyProvider is implemented by backend definitions that want to
+// override the sidecar-wide restart policy for themselves.
+type restartPolicyProvider interface {
+	RestartPolicy() *RestartPolicyConfig
 }
 
 func init() {
 	if err := RegisterBackendRunner("exec", NewExecRunner); err != nil {
-		log.Fatal(err)
+		hclog.Default().Error("failed to register exec runner", "error", err)
+		os.Exit(1)
 	}
 }
 
 func NewExecRunner(backend backends.Backend, context *context.Ctx) Runner {
+	policyConfig := restartPolicyConfigFor(backend, context)
 	r := &ExecRunner{
 		RunnerCommon: RunnerCommon{
 			name:    backend.Name(),
@@ -59,14 +96,53 @@ func NewExecRunner(backend backends.Backend, context *context.Ctx) Runner {
 		exec:         backend.ExecPath(),
 		args:         backend.ExecArgs(),
 		isRunning:    false,
-		restartCount: 1,
+		policyConfig: policyConfig,
+		policy:       NewRestartPolicy(policyConfig),
 		stderr:       filepath.Join(context.UserConfig.LogPath, backend.Name()+"_stderr.log"),
 		stdout:       filepath.Join(context.UserConfig.LogPath, backend.Name()+"_stdout.log"),
+		logger:       NewLogger(context, "daemon").With("backend", backend.Name(), "runner", "exec"),
 	}
 
+	r.reapOrphan()
+
 	return r
 }
 
+// reapOrphan rehydrates the last-known state persisted by a previous
+// sidecar process and, if it describes a collector that is still running,
+// kills it. Without this a sidecar crash leaves its collector running
+// unmanaged forever, since nothing else holds its PID.
+func (r *ExecRunner) reapOrphan() {
+	state, err := filestatus.Load(r.context.UserConfig.CachePath, r.name)
+	if err != nil {
+		r.logger.Error("failed to load persisted runner state", "error", err)
+		return
+	}
+	if state == nil || !state.Running || !filestatus.ProcessAlive(state.Pid) {
+		return
+	}
+
+	r.logger.Info("found orphaned collector process from a previous sidecar run, reaping it", "pid", state.Pid)
+	if proc, err := os.FindProcess(state.Pid); err == nil {
+		proc.Kill()
+	}
+}
+
+// restartPolicyConfigFor resolves the effective RestartPolicyConfig for
+// backend: its own override if it provides one, otherwise the sidecar-wide
+// default from UserConfig.
+func restartPolicyConfigFor(backend backends.Backend, context *context.Ctx) RestartPolicyConfig {
+	if provider, ok := backend.(restartPolicyProvider); ok {
+		if override := provider.RestartPolicy(); override != nil {
+			return *override
+		}
+	}
+	if context.UserConfig.RestartPolicy != (RestartPolicyConfig{}) {
+		return context.UserConfig.RestartPolicy
+	}
+	return DefaultRestartPolicyConfig()
+}
+
 func (r *ExecRunner) Name() string {
 	return r.name
 }
@@ -97,51 +173,177 @@ func (r *ExecRunner) ValidateBeforeStart() error {
 
 func (r *ExecRunner) Start(s service.Service) error {
 	if err := r.ValidateBeforeStart(); err != nil {
-		log.Error(err.Error())
+		r.logger.Error(err.Error())
 		return err
 	}
 
-	r.restartCount = 1
+	lock, err := filelock.Acquire(r.context.UserConfig.CachePath, r.name)
+	if err != nil {
+		return backends.SetStatusLogErrorf(r.name, "Refusing to start %q, another sidecar process already owns it: %v", r.name, err)
+	}
+	r.lock = lock
+
+	r.attempt = 0
+	r.stopRequested = false
+	r.saveState(0, 0, false)
 	r.wg.Add(1)
 	go func() {
 		defer r.wg.Done()
 		for {
+			control, err := newControlServer(r.name, r.logger.Named("control"))
+			if err != nil {
+				backends.SetStatusLogErrorf(r.name, "Failed to set up control channel: %v", err)
+			}
+			addr := ""
+			if control != nil {
+				if addr, err = control.Start(); err != nil {
+					backends.SetStatusLogErrorf(r.name, "Failed to start control channel: %v", err)
+					control = nil
+				}
+			}
+			r.control = control
+
 			r.cmd = exec.Command(r.exec, r.args...)
 			r.cmd.Dir = r.daemon.Dir
 			r.cmd.Env = append(os.Environ(), r.daemon.Env...)
+			if control != nil {
+				r.cmd.Env = append(r.cmd.Env,
+					rpcclient.EnvControlAddr+"="+addr,
+					rpcclient.EnvControlToken+"="+control.token)
+			}
+
 			r.startTime = time.Now()
+			watchdogDone := make(chan struct{})
+			if control != nil {
+				go r.watchControlChannel(control, watchdogDone)
+			}
 			r.run()
+			close(watchdogDone)
+			if control != nil {
+				control.Stop()
+			}
+			uptime := time.Since(r.startTime)
 
 			// A backend should stay alive longer than 3 seconds
-			if time.Since(r.startTime) < 3*time.Second {
+			if uptime < 3*time.Second {
 				backends.SetStatusLogErrorf(r.name, "Collector exits immediately, this should not happen! Please check your collector configuration!")
 			}
-			// After 60 seconds we can reset the restart counter
-			if time.Since(r.startTime) > 60*time.Second {
-				r.restartCount = 0
+			// Once the process has been up longer than the configured
+			// healthy window we no longer hold its crash against it.
+			if uptime > r.healthyUptime() {
+				r.attempt = 0
+			}
+
+			exitErr := r.exitError()
+			restart := r.isRunning && r.policy.ShouldRestart(r.attempt, exitErr, r.stopRequested)
+			delay := time.Duration(0)
+			if restart {
+				delay = r.policy.NextDelay(r.attempt)
+			}
+			event := RestartEvent{
+				Backend:   r.name,
+				Attempt:   r.attempt,
+				ExitCode:  exitCode(exitErr),
+				NextDelay: delay,
+				Restarted: restart,
 			}
-			if r.restartCount <= 3 && r.isRunning {
-				log.Errorf("[%s] Backend crashed, trying to restart %d/3", r.name, r.restartCount)
-				time.Sleep(5 * time.Second)
-				r.restartCount += 1
-				continue
-				// giving up
-			} else if r.restartCount > 3 {
-				backends.SetStatusLogErrorf(r.name, "Collector failed to start after 3 tries!")
+			r.logger.With("attempt", event.Attempt).Info(describeRestartEvent(event),
+				"exit_code", event.ExitCode, "next_delay", event.NextDelay, "restarted", event.Restarted)
+			r.saveState(0, exitCode(exitErr), restart)
+			if !restart {
+				if r.isRunning && !r.stopRequested {
+					backends.SetStatusLogErrorf(r.name, "Collector gave up restarting after %d attempts", r.attempt)
+				}
+				r.isRunning = false
+				break
 			}
 
-			r.isRunning = false
-			break
+			r.attempt += 1
+			time.Sleep(delay)
 		}
 	}()
 	return nil
 }
 
+// watchControlChannel waits for the collector to check in and, once it
+// has, kills the process on a missed heartbeat so the restart-policy loop
+// in Start treats it like any other crash. It exits once done is closed.
+func (r *ExecRunner) watchControlChannel(control *controlServer, done <-chan struct{}) {
+	if err := control.WaitForCheckIn(controlCheckInTimeout); err != nil {
+		r.logger.Info("falling back to process-exit supervision", "reason", err)
+		return
+	}
+
+	ticker := time.NewTicker(defaultHeartbeatDeadline / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if control.MissedHeartbeat() && r.cmd.Process != nil {
+				backends.SetStatusLogErrorf(r.name, "Collector missed its heartbeat deadline, restarting")
+				r.cmd.Process.Kill()
+				return
+			}
+		}
+	}
+}
+
+// healthyUptime returns the duration a backend must stay up before its
+// attempt counter is reset. It reads r.policyConfig - the RestartPolicyConfig
+// restartPolicyConfigFor actually resolved for this backend at construction
+// time, which may come from backend.(restartPolicyProvider) rather than
+// context.UserConfig.RestartPolicy - so a backend overriding MinHealthyUptime
+// gets that override honored here too, not just in the backoff math.
+func (r *ExecRunner) healthyUptime() time.Duration {
+	if r.policyConfig.MinHealthyUptime > 0 {
+		return r.policyConfig.MinHealthyUptime
+	}
+	return DefaultRestartPolicyConfig().MinHealthyUptime
+}
+
+// exitError returns the error from the last completed r.cmd.Run(), or nil
+// if the process exited cleanly. A failure to even start the process (the
+// binary disappeared, a permission race, ENOENT/EAGAIN, ...) is treated as
+// a failure too, not a clean exit, since otherwise the restart policy sees
+// ExitCode 0 and an OnFailure-style policy gives up after a single attempt.
+func (r *ExecRunner) exitError() error {
+	if r.startFailed != nil {
+		return r.startFailed
+	}
+	if r.cmd == nil || r.cmd.ProcessState == nil {
+		return nil
+	}
+	if r.cmd.ProcessState.Success() {
+		return nil
+	}
+	return &exec.ExitError{ProcessState: r.cmd.ProcessState}
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func describeRestartEvent(e RestartEvent) string {
+	if e.Restarted {
+		return fmt.Sprintf("Backend crashed (exit %d), restarting attempt %d in %s", e.ExitCode, e.Attempt+1, e.NextDelay)
+	}
+	return fmt.Sprintf("Backend stopped (exit %d) after %d attempt(s), not restarting", e.ExitCode, e.Attempt)
+}
+
 func (r *ExecRunner) Stop(s service.Service) error {
-	log.Infof("[%s] Stopping", r.name)
+	r.logger.Info("stopping")
 
 	// deactivate supervisor
 	r.isRunning = false
+	r.stopRequested = true
 
 	// give the chance to cleanup resources
 	if r.cmd.Process != nil {
@@ -157,6 +359,11 @@ func (r *ExecRunner) Stop(s service.Service) error {
 	// wait for background routine to finish
 	r.wg.Wait()
 
+	r.saveState(0, 0, false)
+	if err := r.lock.Release(); err != nil {
+		r.logger.Error("failed to release file lock", "error", err)
+	}
+
 	return nil
 }
 
@@ -169,7 +376,7 @@ func (r *ExecRunner) Restart(s service.Service) error {
 }
 
 func (r *ExecRunner) run() {
-	log.Infof("[%s] Starting (%s driver)", r.name, r.backend.Driver())
+	r.logger.Info("starting", "driver", r.backend.Driver())
 
 	if r.stderr != "" {
 		err := common.CreatePathToFile(r.stderr)
@@ -194,7 +401,124 @@ func (r *ExecRunner) run() {
 
 	r.isRunning = true
 	r.backend.SetStatus(backends.StatusRunning, "Running")
-	r.cmd.Run()
+
+	if err := r.cmd.Start(); err != nil {
+		backends.SetStatusLogErrorf(r.name, "Failed to start collector process: %v", err)
+		r.startFailed = err
+		return
+	}
+	r.startFailed = nil
+	r.saveState(r.cmd.Process.Pid, 0, true)
+	r.logger.With("pid", r.cmd.Process.Pid).Info("collector process started")
+
+	r.sampler = newStatsSampler(r.name, r.cmd.Process.Pid, r.statsInterval(), r.statsThresholds(), r.logger.Named("stats"))
+	go r.sampler.Run()
+	go r.publishStats(r.sampler)
+
+	r.cmd.Wait()
+	r.sampler.Stop()
 
 	return
 }
+
+// saveState persists the runner's current transition to
+// <cache>/state/<name>.json so a sidecar restart can rehydrate it.
+func (r *ExecRunner) saveState(pid, lastExitCode int, running bool) {
+	state := &filestatus.State{
+		Name:         r.name,
+		Pid:          pid,
+		StartTime:    r.startTime,
+		RestartCount: r.attempt,
+		LastExitCode: lastExitCode,
+		Running:      running,
+	}
+	if err := filestatus.Save(r.context.UserConfig.CachePath, state); err != nil {
+		r.logger.Error("failed to persist runner state", "error", err)
+	}
+}
+
+// statsInterval reads the sampling interval from UserConfig, falling back
+// to defaultStatsInterval.
+func (r *ExecRunner) statsInterval() time.Duration {
+	if r.context.UserConfig.StatsInterval > 0 {
+		return r.context.UserConfig.StatsInterval
+	}
+	return defaultStatsInterval
+}
+
+// statsThresholds reads the WARN thresholds from UserConfig.
+func (r *ExecRunner) statsThresholds() statsThresholds {
+	return statsThresholds{
+		MaxRSSBytes:   r.context.UserConfig.StatsMaxRSSBytes,
+		MaxCPUPercent: r.context.UserConfig.StatsMaxCPUPercent,
+	}
+}
+
+// publishStats periodically pushes samples to the Graylog server through
+// the backend's statsPublisher, if it has one, so operators can chart
+// collector resource usage per host.
+func (r *ExecRunner) publishStats(sampler *statsSampler) {
+	publisher, ok := r.backend.(statsPublisher)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(r.statsInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		usage := sampler.Latest()
+		if usage == nil {
+			continue
+		}
+		if err := publisher.PublishStats(usage); err != nil {
+			r.logger.Debug("failed to publish resource usage", "error", err)
+		}
+		select {
+		case <-sampler.done:
+			return
+		default:
+		}
+	}
+}
+
+// PushConfig implements nxlog.ConfigPusher (and any other backend's
+// equivalent): it forwards values to the running collector over the
+// loopback control channel instead of requiring a restart. It fails if
+// the current process generation hasn't set up a control channel, or the
+// collector hasn't checked in over it yet.
+func (r *ExecRunner) PushConfig(values map[string]interface{}) error {
+	if r.control == nil {
+		return fmt.Errorf("[%s] no control channel available for this process generation", r.name)
+	}
+	select {
+	case <-r.control.checkedIn:
+	default:
+		return fmt.Errorf("[%s] collector has not checked in over the control channel yet", r.name)
+	}
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	r.control.PushConfig(&pb.ConfigUpdate{Format: "json", Payload: payload})
+	return nil
+}
+
+// Update implements jobmgr.Updater, letting jobmgr apply a discovered
+// config change in place rather than restarting the collector process.
+func (r *ExecRunner) Update(values map[string]interface{}) error {
+	return r.PushConfig(values)
+}
+
+// LatestStats implements StatsReporter.
+func (r *ExecRunner) LatestStats(name string) (*BackendResourceUsage, error) {
+	if r.name != name {
+		return nil, fmt.Errorf("unknown backend %q", name)
+	}
+	if r.sampler == nil {
+		return nil, fmt.Errorf("[%s] no resource usage samples yet", name)
+	}
+	usage := r.sampler.Latest()
+	if usage == nil {
+		return nil, fmt.Errorf("[%s] no resource usage samples yet", name)
+	}
+	return usage, nil
+}
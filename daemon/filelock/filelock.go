@@ -0,0 +1,81 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package filelock provides an OS-level advisory lock per backend so two
+// ExecRunner instances (in this sidecar or, crucially, in a second sidecar
+// process pointed at the same collector) can never both believe they own
+// the same PID/log/spool paths.
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrLocked is returned by Acquire when another process already holds the
+// lock for this backend.
+var ErrLocked = fmt.Errorf("lock is already held by another process")
+
+// Lock is a held advisory lock on a single backend's lock file. The zero
+// value is not usable; obtain one via Acquire.
+type Lock struct {
+	file *os.File
+	path string
+}
+
+// PathFor returns the lock file path for backend name under cacheDir,
+// matching the layout daemon/filestatus uses for its state files.
+func PathFor(cacheDir, name string) string {
+	return filepath.Join(cacheDir, "locks", name+".lock")
+}
+
+// Acquire opens (creating if necessary) the lock file for name under
+// cacheDir and attempts to take an exclusive, non-blocking advisory lock on
+// it. It returns ErrLocked if another process already holds it.
+func Acquire(cacheDir, name string) (*Lock, error) {
+	path := PathFor(cacheDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("filelock: failed to create lock dir: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("filelock: failed to open lock file %q: %w", path, err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Record our PID for operators debugging a stuck lock; best effort.
+	f.Truncate(0)
+	f.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+
+	return &Lock{file: f, path: path}, nil
+}
+
+// Release drops the lock and closes the underlying file. It is safe to call
+// on a nil *Lock.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	if err := unlockFile(l.file); err != nil {
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,54 @@
+package filelock
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestAcquireThenReleaseAllowsReacquire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filelock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lock, err := Acquire(dir, "exec")
+	if err != nil {
+		t.Fatalf("first Acquire() error = %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	second, err := Acquire(dir, "exec")
+	if err != nil {
+		t.Fatalf("Acquire() after Release() error = %v, want success", err)
+	}
+	second.Release()
+}
+
+func TestAcquireRefusesWhileAlreadyHeld(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filelock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lock, err := Acquire(dir, "exec")
+	if err != nil {
+		t.Fatalf("first Acquire() error = %v", err)
+	}
+	defer lock.Release()
+
+	if _, err := Acquire(dir, "exec"); err == nil {
+		t.Error("second Acquire() on the same backend succeeded, want it refused while the first lock is held")
+	}
+}
+
+func TestReleaseOnNilLockIsSafe(t *testing.T) {
+	var l *Lock
+	if err := l.Release(); err != nil {
+		t.Errorf("Release() on a nil *Lock returned %v, want nil", err)
+	}
+}
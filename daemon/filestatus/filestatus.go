@@ -0,0 +1,104 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package filestatus persists the last-known state of a runner to disk so a
+// sidecar restart can reattach to (or cleanly reap) an already-running
+// collector process instead of orphaning it.
+package filestatus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is the last-known transition of a single backend's runner.
+type State struct {
+	Name         string    `json:"name"`
+	Pid          int       `json:"pid"`
+	StartTime    time.Time `json:"start_time"`
+	RestartCount int       `json:"restart_count"`
+	LastExitCode int       `json:"last_exit_code"`
+	Running      bool      `json:"running"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PathFor returns the state file path for backend name under cacheDir.
+func PathFor(cacheDir, name string) string {
+	return filepath.Join(cacheDir, "state", name+".json")
+}
+
+// Save atomically persists state to <cacheDir>/state/<name>.json via
+// write-temp+rename, so a crash mid-write never leaves a corrupt file
+// behind for Load to choke on.
+func Save(cacheDir string, state *State) error {
+	state.UpdatedAt = time.Now()
+	path := PathFor(cacheDir, state.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("filestatus: failed to create state dir: %w", err)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("filestatus: failed to marshal state: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+state.Name+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("filestatus: failed to create temp state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("filestatus: failed to write temp state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("filestatus: failed to close temp state file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("filestatus: failed to rename temp state file into place: %w", err)
+	}
+	return nil
+}
+
+// Load rehydrates the last-known state for name from cacheDir. It returns
+// (nil, nil) if no state file exists yet, e.g. on first sidecar startup.
+func Load(cacheDir, name string) (*State, error) {
+	path := PathFor(cacheDir, name)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filestatus: failed to read state file %q: %w", path, err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("filestatus: failed to parse state file %q: %w", path, err)
+	}
+	return &state, nil
+}
+
+// ProcessAlive reports whether pid still refers to a live process.
+// Implemented per-OS in filestatus_unix.go/filestatus_windows.go since
+// os.Process.Signal's semantics differ too much between them to share one
+// implementation.
+
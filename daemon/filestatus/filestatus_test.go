@@ -0,0 +1,71 @@
+package filestatus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveThenLoadRoundTrips(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestatus-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := &State{Name: "exec", Pid: 1234, RestartCount: 2, LastExitCode: 1, Running: true}
+	if err := Save(dir, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := Load(dir, "exec")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got == nil {
+		t.Fatal("Load() = nil, want the state just saved")
+	}
+	if got.Pid != want.Pid || got.RestartCount != want.RestartCount || got.Running != want.Running {
+		t.Errorf("Load() = %+v, want fields matching %+v", got, want)
+	}
+	if got.UpdatedAt.IsZero() {
+		t.Error("Save() should stamp UpdatedAt")
+	}
+}
+
+func TestLoadMissingFileReturnsNilNil(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestatus-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state, err := Load(dir, "never-saved")
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil for a missing state file", err)
+	}
+	if state != nil {
+		t.Errorf("Load() = %+v, want nil", state)
+	}
+}
+
+func TestSaveLeavesNoTempFileBehind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestatus-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := Save(dir, &State{Name: "exec"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "exec.json" {
+		t.Errorf("state dir contains %v, want exactly exec.json (no leftover .tmp file)", entries)
+	}
+}
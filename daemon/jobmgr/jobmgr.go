@@ -0,0 +1,197 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package jobmgr consumes confgroup.Group events from backends/discovery
+// and starts, stops or updates the corresponding daemon.Runner for each
+// backend. It replaces the single, statically registered backend per
+// process that NxConfig and ExecRunner used to assume: every backend
+// discovered, from any source, becomes an independent job with its own
+// lifecycle.
+package jobmgr
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/kardianos/service"
+
+	"github.com/Graylog2/collector-sidecar/backends"
+	"github.com/Graylog2/collector-sidecar/backends/confgroup"
+	"github.com/Graylog2/collector-sidecar/context"
+	"github.com/Graylog2/collector-sidecar/daemon"
+)
+
+// runnerFactories mirrors the registrations daemon.RegisterBackendRunner
+// holds for the sidecar's static, single-backend startup path. That
+// registry isn't exported for lookup, so jobmgr keeps its own handle on
+// the same factories for the drivers it knows how to run dynamically; add
+// an entry here alongside any new daemon.Runner implementation's init().
+var runnerFactories = map[string]func(backends.Backend, *context.Ctx) daemon.Runner{
+	"exec": daemon.NewExecRunner,
+}
+
+// Updater is implemented by runners and backend definitions that can apply
+// a config change without restarting the process, e.g. nxlog.ConfigUpdater
+// (embedded in nxlog.Backend) or daemon.ExecRunner pushing a delta over the
+// gRPC control channel instead of rewriting the on-disk config and killing
+// the process. Jobs where neither the runner nor the backend implements
+// Updater, or where Update fails, are restarted on change.
+type Updater interface {
+	Update(values map[string]interface{}) error
+}
+
+// job tracks the running daemon.Runner for one confgroup.Config.
+type job struct {
+	config  confgroup.Config
+	backend backends.Backend
+	runner  daemon.Runner
+}
+
+// Manager owns every running job and reconciles them against the latest
+// confgroup.Group seen per source.
+type Manager struct {
+	context *context.Ctx
+	service service.Service
+	logger  hclog.Logger
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	groups map[string]*confgroup.Group
+}
+
+// NewManager builds a Manager. Call Run with the discovery.Manager's event
+// channel to start reconciling.
+func NewManager(ctx *context.Ctx, svc service.Service) *Manager {
+	return &Manager{
+		context: ctx,
+		service: svc,
+		logger:  daemon.NewLogger(ctx, "jobmgr"),
+		jobs:    make(map[string]*job),
+		groups:  make(map[string]*confgroup.Group),
+	}
+}
+
+// Run consumes Groups from events until it's closed, starting, updating or
+// stopping jobs as they change. It blocks the calling goroutine.
+func (m *Manager) Run(events <-chan *confgroup.Group) {
+	for group := range events {
+		m.reconcile(group)
+	}
+}
+
+// Stop stops every currently running job. Used on sidecar shutdown.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, j := range m.jobs {
+		m.stopJob(j)
+		delete(m.jobs, key)
+	}
+}
+
+func (m *Manager) reconcile(group *confgroup.Group) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev := m.groups[group.Source]
+	added, updated, removed := group.Diff(prev)
+	m.groups[group.Source] = group
+
+	for _, c := range removed {
+		if j, ok := m.jobs[c.Key()]; ok {
+			m.stopJob(j)
+			delete(m.jobs, c.Key())
+		}
+	}
+	for _, c := range added {
+		j, err := m.startJob(c)
+		if err != nil {
+			m.logger.Error("failed to start job", "job", c.Key(), "error", err)
+			continue
+		}
+		m.jobs[c.Key()] = j
+	}
+	for _, c := range updated {
+		m.updateJob(c)
+	}
+}
+
+func (m *Manager) startJob(c confgroup.Config) (*job, error) {
+	backend, err := backends.NewFromConfig(c.Driver, c.Name, c.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	newRunner, ok := runnerFactories[c.Driver]
+	if !ok {
+		return nil, fmt.Errorf("no runner registered for driver %q", c.Driver)
+	}
+	runner := newRunner(backend, m.context)
+	runner.BindToService(m.service)
+	runner.SetDaemon(&daemon.DaemonConfig{})
+	if err := runner.Start(m.service); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("started job", "job", c.Name, "driver", c.Driver, "source", c.Source)
+	return &job{config: c, backend: backend, runner: runner}, nil
+}
+
+func (m *Manager) stopJob(j *job) {
+	if err := j.runner.Stop(m.service); err != nil {
+		m.logger.Error("failed to stop job", "job", j.config.Key(), "error", err)
+		return
+	}
+	m.logger.Info("stopped job", "job", j.config.Key())
+}
+
+func (m *Manager) updateJob(c confgroup.Config) {
+	j, ok := m.jobs[c.Key()]
+	if !ok {
+		added, err := m.startJob(c)
+		if err != nil {
+			m.logger.Error("failed to start updated job", "job", c.Key(), "error", err)
+			return
+		}
+		m.jobs[c.Key()] = added
+		return
+	}
+
+	// A runner that owns a live control channel to its collector (e.g.
+	// ExecRunner, see its Update) can usually push a delta over the wire
+	// more cheaply than a backend-level Updater can, so it's tried first.
+	updater, ok := j.runner.(Updater)
+	if !ok {
+		updater, ok = j.backend.(Updater)
+	}
+	if ok {
+		if err := updater.Update(c.Values); err == nil {
+			j.config = c
+			m.logger.Info("updated job in place", "job", c.Key())
+			return
+		}
+		m.logger.Error("in-place update failed, restarting job", "job", c.Key())
+	}
+
+	m.stopJob(j)
+	restarted, err := m.startJob(c)
+	if err != nil {
+		m.logger.Error("failed to restart job after update", "job", c.Key(), "error", err)
+		delete(m.jobs, c.Key())
+		return
+	}
+	m.jobs[c.Key()] = restarted
+}
@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffPolicyNextDelay(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     RestartPolicyConfig
+		attempt int
+		want    time.Duration
+	}{
+		{
+			name:    "doubles with attempt",
+			cfg:     RestartPolicyConfig{InitialBackoff: time.Second, MaxBackoff: time.Minute},
+			attempt: 2,
+			want:    4 * time.Second,
+		},
+		{
+			name:    "caps at max backoff",
+			cfg:     RestartPolicyConfig{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second},
+			attempt: 5,
+			want:    3 * time.Second,
+		},
+		{
+			name:    "zero initial backoff means no delay",
+			cfg:     RestartPolicyConfig{InitialBackoff: 0, MaxBackoff: time.Minute},
+			attempt: 3,
+			want:    0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := backoffPolicy{c.cfg}
+			if got := p.NextDelay(c.attempt); got != c.want {
+				t.Errorf("NextDelay(%d) = %s, want %s", c.attempt, got, c.want)
+			}
+		})
+	}
+}
+
+func TestBackoffPolicyNextDelayJitterStaysWithinBounds(t *testing.T) {
+	cfg := RestartPolicyConfig{InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute, JitterFraction: 0.5}
+	p := backoffPolicy{cfg}
+
+	for i := 0; i < 50; i++ {
+		delay := p.NextDelay(0)
+		if delay < 5*time.Second || delay > 15*time.Second {
+			t.Fatalf("NextDelay with 50%% jitter on a 10s base produced %s, want within [5s, 15s]", delay)
+		}
+	}
+}
+
+func TestAttemptsExhausted(t *testing.T) {
+	p := backoffPolicy{RestartPolicyConfig{MaxAttempts: 3}}
+
+	if p.attemptsExhausted(2) {
+		t.Error("attempt 2 of 3 should not be exhausted")
+	}
+	if !p.attemptsExhausted(3) {
+		t.Error("attempt 3 of 3 should be exhausted")
+	}
+
+	unbounded := backoffPolicy{RestartPolicyConfig{MaxAttempts: 0}}
+	if unbounded.attemptsExhausted(1000) {
+		t.Error("MaxAttempts: 0 should mean unlimited attempts")
+	}
+}
+
+func TestOnFailurePolicyShouldRestart(t *testing.T) {
+	p := &onFailurePolicy{backoffPolicy{RestartPolicyConfig{MaxAttempts: 2}}}
+	failure := errors.New("exit status 1")
+
+	if p.ShouldRestart(0, nil, false) {
+		t.Error("a clean exit should not be restarted by OnFailure")
+	}
+	if !p.ShouldRestart(0, failure, false) {
+		t.Error("a crash within the attempt budget should be restarted")
+	}
+	if p.ShouldRestart(2, failure, false) {
+		t.Error("a crash past the attempt budget should not be restarted")
+	}
+	if p.ShouldRestart(0, failure, true) {
+		t.Error("a crash after Stop() was requested should not be restarted")
+	}
+}
+
+func TestUnlessStoppedPolicyRestartsOnCleanExit(t *testing.T) {
+	p := &unlessStoppedPolicy{backoffPolicy{RestartPolicyConfig{MaxAttempts: 3}}}
+
+	if !p.ShouldRestart(0, nil, false) {
+		t.Error("RestartUnlessStopped should restart on a clean exit, matching the old always-restart loop")
+	}
+	if p.ShouldRestart(0, nil, true) {
+		t.Error("RestartUnlessStopped should not restart once Stop() was requested")
+	}
+	if p.ShouldRestart(3, nil, false) {
+		t.Error("RestartUnlessStopped should still honor the attempt budget")
+	}
+}
+
+func TestNeverPolicyNeverRestarts(t *testing.T) {
+	p := &neverPolicy{}
+	if p.ShouldRestart(0, errors.New("boom"), false) {
+		t.Error("RestartNever should never restart")
+	}
+}
+
+func TestDefaultRestartPolicyConfigMatchesPriorBehavior(t *testing.T) {
+	cfg := DefaultRestartPolicyConfig()
+	if cfg.Policy != RestartUnlessStopped {
+		t.Errorf("default Policy = %s, want %s to match the old restart-on-any-exit loop", cfg.Policy, RestartUnlessStopped)
+	}
+	if cfg.MaxAttempts != 3 {
+		t.Errorf("default MaxAttempts = %d, want 3", cfg.MaxAttempts)
+	}
+}
@@ -0,0 +1,244 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// BackendResourceUsage is a single point-in-time resource sample for a
+// running backend process.
+type BackendResourceUsage struct {
+	Name       string
+	Timestamp  time.Time
+	CPUPercent float64
+	RSSBytes   uint64
+	VSZBytes   uint64
+	Threads    int
+	ReadBytes  uint64
+	WriteBytes uint64
+	Uptime     time.Duration
+}
+
+// StatsReporter is implemented by runners that can report the resource
+// usage of the backend process they supervise. Nothing in this tree calls
+// it yet - it's the extension point whatever exposes resource usage to an
+// operator (a server-facing API handler, a CLI command) is meant to use,
+// the same way ExecRunner.PushConfig is a ready extension point for
+// backends that don't exist here either.
+type StatsReporter interface {
+	// LatestStats returns the most recent sample for name, or an error if
+	// no sample has been taken yet (e.g. the backend isn't running).
+	LatestStats(name string) (*BackendResourceUsage, error)
+}
+
+// statsThresholds triggers a WARN log when crossed, so operators can catch
+// a runaway collector before it OOMs the box.
+type statsThresholds struct {
+	MaxRSSBytes   uint64
+	MaxCPUPercent float64
+}
+
+// statsRingBuffer keeps the last N samples for a single backend plus the
+// rolling max seen across its whole lifetime (reset on every restart).
+type statsRingBuffer struct {
+	mu      sync.Mutex
+	samples []*BackendResourceUsage
+	size    int
+	next    int
+	count   int
+	maxRSS  uint64
+	maxCPU  float64
+}
+
+func newStatsRingBuffer(size int) *statsRingBuffer {
+	if size <= 0 {
+		size = 60
+	}
+	return &statsRingBuffer{samples: make([]*BackendResourceUsage, size), size: size}
+}
+
+// Add records a sample and updates the rolling max.
+func (b *statsRingBuffer) Add(sample *BackendResourceUsage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.samples[b.next] = sample
+	b.next = (b.next + 1) % b.size
+	if b.count < b.size {
+		b.count++
+	}
+	if sample.RSSBytes > b.maxRSS {
+		b.maxRSS = sample.RSSBytes
+	}
+	if sample.CPUPercent > b.maxCPU {
+		b.maxCPU = sample.CPUPercent
+	}
+}
+
+// Latest returns the most recently added sample, or nil if none exist yet.
+func (b *statsRingBuffer) Latest() *BackendResourceUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.count == 0 {
+		return nil
+	}
+	idx := (b.next - 1 + b.size) % b.size
+	return b.samples[idx]
+}
+
+// Max returns the rolling max RSS and CPU% observed since the buffer was
+// created.
+func (b *statsRingBuffer) Max() (rss uint64, cpu float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.maxRSS, b.maxCPU
+}
+
+// rawSample is the set of OS counters collected on each tick; platform
+// files turn it into a BackendResourceUsage by diffing against the
+// previous tick.
+type rawSample struct {
+	Timestamp  time.Time
+	UserTime   time.Duration
+	SystemTime time.Duration
+	RSSBytes   uint64
+	VSZBytes   uint64
+	Threads    int
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// sampleProcess is implemented per-OS in stats_linux.go, stats_windows.go
+// and stats_darwin.go.
+//
+// func sampleProcess(pid int) (*rawSample, error)
+
+// statsSampler polls a single process on interval and feeds a
+// statsRingBuffer until stopped.
+type statsSampler struct {
+	name       string
+	pid        int
+	interval   time.Duration
+	thresholds statsThresholds
+	buffer     *statsRingBuffer
+	startTime  time.Time
+	logger     hclog.Logger
+
+	stop chan struct{}
+	done chan struct{}
+
+	prev *rawSample
+}
+
+// defaultStatsInterval is used when UserConfig doesn't configure one.
+const defaultStatsInterval = 10 * time.Second
+
+// defaultStatsBufferSize keeps ten minutes of history at the default
+// interval.
+const defaultStatsBufferSize = 60
+
+func newStatsSampler(name string, pid int, interval time.Duration, thresholds statsThresholds, logger hclog.Logger) *statsSampler {
+	if interval <= 0 {
+		interval = defaultStatsInterval
+	}
+	return &statsSampler{
+		name:       name,
+		pid:        pid,
+		interval:   interval,
+		thresholds: thresholds,
+		buffer:     newStatsRingBuffer(defaultStatsBufferSize),
+		startTime:  time.Now(),
+		logger:     logger,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run samples the process on s.interval until Stop is called or the process
+// can no longer be read (it exited).
+func (s *statsSampler) Run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if !s.tick() {
+				return
+			}
+		}
+	}
+}
+
+func (s *statsSampler) tick() bool {
+	raw, err := sampleProcess(s.pid)
+	if err != nil {
+		s.logger.Debug("stopped sampling resource usage", "error", err)
+		return false
+	}
+
+	usage := &BackendResourceUsage{
+		Name:      s.name,
+		Timestamp: raw.Timestamp,
+		RSSBytes:  raw.RSSBytes,
+		VSZBytes:  raw.VSZBytes,
+		Threads:   raw.Threads,
+		Uptime:    time.Since(s.startTime),
+	}
+
+	if s.prev != nil {
+		wallDelta := raw.Timestamp.Sub(s.prev.Timestamp).Seconds()
+		cpuDelta := (raw.UserTime + raw.SystemTime) - (s.prev.UserTime + s.prev.SystemTime)
+		if wallDelta > 0 {
+			usage.CPUPercent = cpuDelta.Seconds() / wallDelta * 100
+		}
+		usage.ReadBytes = raw.ReadBytes - s.prev.ReadBytes
+		usage.WriteBytes = raw.WriteBytes - s.prev.WriteBytes
+	}
+	s.prev = raw
+
+	s.buffer.Add(usage)
+	s.checkThresholds(usage)
+	return true
+}
+
+func (s *statsSampler) checkThresholds(usage *BackendResourceUsage) {
+	if s.thresholds.MaxRSSBytes > 0 && usage.RSSBytes > s.thresholds.MaxRSSBytes {
+		s.logger.Warn("RSS exceeds configured threshold", "rss_bytes", usage.RSSBytes, "threshold_bytes", s.thresholds.MaxRSSBytes)
+	}
+	if s.thresholds.MaxCPUPercent > 0 && usage.CPUPercent > s.thresholds.MaxCPUPercent {
+		s.logger.Warn("CPU exceeds configured threshold", "cpu_percent", usage.CPUPercent, "threshold_percent", s.thresholds.MaxCPUPercent)
+	}
+}
+
+// Stop halts sampling and waits for the sampler goroutine to exit.
+func (s *statsSampler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// Latest returns the most recent sample, or nil if none has been taken yet.
+func (s *statsSampler) Latest() *BackendResourceUsage {
+	return s.buffer.Latest()
+}
@@ -0,0 +1,49 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+package daemon
+
+/*
+#include <libproc.h>
+#include <string.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// sampleProcess reads CPU, memory and thread counters for pid via
+// proc_pidinfo(PROC_PIDTASKINFO). IO counters are not read, so ReadBytes
+// and WriteBytes stay zero on macOS.
+func sampleProcess(pid int) (*rawSample, error) {
+	var info C.struct_proc_taskinfo
+	size := C.int(C.sizeof_struct_proc_taskinfo)
+	n := C.proc_pidinfo(C.int(pid), C.PROC_PIDTASKINFO, 0, unsafe.Pointer(&info), size)
+	if n != size {
+		return nil, fmt.Errorf("proc_pidinfo(%d): %d", pid, n)
+	}
+
+	return &rawSample{
+		Timestamp:  time.Now(),
+		UserTime:   time.Duration(info.pti_total_user) * time.Nanosecond,
+		SystemTime: time.Duration(info.pti_total_system) * time.Nanosecond,
+		RSSBytes:   uint64(info.pti_resident_size),
+		VSZBytes:   uint64(info.pti_virtual_size),
+		Threads:    int(info.pti_threadnum),
+	}, nil
+}
@@ -0,0 +1,127 @@
+// This file is part of Graylog.
+//
+// Graylog is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Graylog is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Graylog.  If not, see <http://www.gnu.org/licenses/>.
+
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicksPerSecond is almost universally 100 on Linux (USER_HZ);
+// sysconf(_SC_CLK_TCK) would be the correct source but isn't worth a cgo
+// dependency just for this.
+const clockTicksPerSecond = 100
+
+// sampleProcess reads /proc/<pid>/stat and /proc/<pid>/io for the CPU,
+// memory and IO counters of pid.
+func sampleProcess(pid int) (*rawSample, error) {
+	stat, err := readProcStat(pid)
+	if err != nil {
+		return nil, err
+	}
+	readBytes, writeBytes, err := readProcIO(pid)
+	if err != nil {
+		// Not all kernels/permissions expose /proc/<pid>/io; degrade
+		// gracefully rather than losing CPU/RSS reporting over it.
+		readBytes, writeBytes = 0, 0
+	}
+
+	return &rawSample{
+		Timestamp:  time.Now(),
+		UserTime:   time.Duration(stat.utime) * time.Second / clockTicksPerSecond,
+		SystemTime: time.Duration(stat.stime) * time.Second / clockTicksPerSecond,
+		RSSBytes:   stat.rss * uint64(os.Getpagesize()),
+		VSZBytes:   stat.vsize,
+		Threads:    stat.threads,
+		ReadBytes:  readBytes,
+		WriteBytes: writeBytes,
+	}, nil
+}
+
+type procStat struct {
+	utime, stime uint64
+	rss          uint64
+	vsize        uint64
+	threads      int
+}
+
+// readProcStat parses the fields of /proc/<pid>/stat that matter to us.
+func readProcStat(pid int) (*procStat, error) {
+	raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, err
+	}
+	return parseProcStat(pid, raw)
+}
+
+// parseProcStat does the actual field parsing for readProcStat, split out
+// so it can be exercised against a crafted fixture without a real /proc.
+// The comm field (2nd field) is parenthesized and may itself contain
+// spaces/parens, so we split on the last ')' rather than by whitespace.
+func parseProcStat(pid int, raw []byte) (*procStat, error) {
+	line := string(raw)
+	end := strings.LastIndex(line, ")")
+	if end < 0 {
+		return nil, fmt.Errorf("unexpected /proc/%d/stat format", pid)
+	}
+	fields := strings.Fields(line[end+2:])
+	// Fields are 0-indexed here starting at state (field 3 in `man proc`).
+	const (
+		idxUtime   = 14 - 3
+		idxStime   = 15 - 3
+		idxThreads = 20 - 3
+		idxVsize   = 23 - 3
+		idxRss     = 24 - 3
+	)
+	if len(fields) <= idxRss {
+		return nil, fmt.Errorf("short /proc/%d/stat line", pid)
+	}
+
+	s := &procStat{}
+	s.utime, _ = strconv.ParseUint(fields[idxUtime], 10, 64)
+	s.stime, _ = strconv.ParseUint(fields[idxStime], 10, 64)
+	threads, _ := strconv.Atoi(fields[idxThreads])
+	s.threads = threads
+	s.vsize, _ = strconv.ParseUint(fields[idxVsize], 10, 64)
+	s.rss, _ = strconv.ParseUint(fields[idxRss], 10, 64)
+	return s, nil
+}
+
+// readProcIO returns the cumulative read_bytes/write_bytes counters from
+// /proc/<pid>/io.
+func readProcIO(pid int) (read, write uint64, err error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/io", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "read_bytes:"):
+			read, _ = strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "read_bytes:")), 10, 64)
+		case strings.HasPrefix(line, "write_bytes:"):
+			write, _ = strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "write_bytes:")), 10, 64)
+		}
+	}
+	return read, write, scanner.Err()
+}
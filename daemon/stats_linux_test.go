@@ -0,0 +1,53 @@
+package daemon
+
+import "testing"
+
+// fixtureProcStatLine builds a /proc/<pid>/stat-shaped line with a distinct,
+// recognizable value in every field readProcStat cares about, so a field
+// being read off-by-one shows up as a wrong value rather than a
+// coincidentally-plausible one. Fields after "(comm) " are 0-indexed here
+// starting at state (field 3 per `man proc`), matching parseProcStat's own
+// indexing.
+func fixtureProcStatLine() string {
+	fields := make([]string, 30)
+	for i := range fields {
+		fields[i] = "1"
+	}
+	fields[0] = "S"       // state (field 3)
+	fields[14-3] = "1400" // utime (field 14)
+	fields[15-3] = "1500" // stime (field 15)
+	fields[20-3] = "4"    // num_threads (field 20)
+	fields[23-3] = "9999" // vsize (field 23)
+	fields[24-3] = "55"   // rss, in pages (field 24)
+	return "1234 (mycollector) " + joinFields(fields)
+}
+
+func joinFields(fields []string) string {
+	out := fields[0]
+	for _, f := range fields[1:] {
+		out += " " + f
+	}
+	return out
+}
+
+func TestParseProcStatReadsUtimeAndStimeFromCorrectFields(t *testing.T) {
+	stat, err := parseProcStat(1234, []byte(fixtureProcStatLine()))
+	if err != nil {
+		t.Fatalf("parseProcStat() error = %v", err)
+	}
+	if stat.utime != 1400 {
+		t.Errorf("utime = %d, want 1400 (field 14 per man proc)", stat.utime)
+	}
+	if stat.stime != 1500 {
+		t.Errorf("stime = %d, want 1500 (field 15 per man proc)", stat.stime)
+	}
+	if stat.threads != 4 {
+		t.Errorf("threads = %d, want 4 (field 20 per man proc)", stat.threads)
+	}
+	if stat.vsize != 9999 {
+		t.Errorf("vsize = %d, want 9999 (field 23 per man proc)", stat.vsize)
+	}
+	if stat.rss != 55 {
+		t.Errorf("rss = %d, want 55 (field 24 per man proc)", stat.rss)
+	}
+}
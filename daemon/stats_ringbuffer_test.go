@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func sample(rss uint64, cpu float64) *BackendResourceUsage {
+	return &BackendResourceUsage{Timestamp: time.Unix(0, 0), RSSBytes: rss, CPUPercent: cpu}
+}
+
+func TestStatsRingBufferLatest(t *testing.T) {
+	b := newStatsRingBuffer(2)
+
+	if got := b.Latest(); got != nil {
+		t.Fatalf("Latest() on an empty buffer = %+v, want nil", got)
+	}
+
+	b.Add(sample(100, 1))
+	b.Add(sample(200, 2))
+	if got := b.Latest(); got.RSSBytes != 200 {
+		t.Errorf("Latest().RSSBytes = %d, want 200", got.RSSBytes)
+	}
+
+	// A third Add on a size-2 buffer must wrap around and overwrite the
+	// oldest sample, not grow unbounded.
+	b.Add(sample(300, 3))
+	if got := b.Latest(); got.RSSBytes != 300 {
+		t.Errorf("Latest().RSSBytes after wraparound = %d, want 300", got.RSSBytes)
+	}
+}
+
+func TestStatsRingBufferMaxIsRollingAcrossWraparound(t *testing.T) {
+	b := newStatsRingBuffer(2)
+
+	b.Add(sample(500, 50))
+	b.Add(sample(100, 10))
+	b.Add(sample(200, 20))
+
+	rss, cpu := b.Max()
+	if rss != 500 {
+		t.Errorf("Max() rss = %d, want 500 (the rolling max survives the sample being evicted)", rss)
+	}
+	if cpu != 50 {
+		t.Errorf("Max() cpu = %v, want 50", cpu)
+	}
+}
+
+func TestStatsRingBufferDefaultsSizeWhenNonPositive(t *testing.T) {
+	b := newStatsRingBuffer(0)
+	if len(b.samples) != 60 {
+		t.Errorf("newStatsRingBuffer(0) size = %d, want the default of 60", len(b.samples))
+	}
+}